@@ -2,10 +2,9 @@ package daemon
 
 import (
 	"context"
-	"os"
-	"os/exec"
+	"fmt"
 	"runtime"
-	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -14,34 +13,108 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/container"
 	"github.com/docker/docker/dockerversion"
-	"github.com/docker/docker/pkg/fileutils"
 	"github.com/docker/docker/pkg/parsers/kernel"
 	"github.com/docker/docker/pkg/parsers/operatingsystem"
 	"github.com/docker/docker/pkg/platform"
 	"github.com/docker/docker/pkg/sysinfo"
-	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
 	"github.com/docker/docker/volume/drivers"
 	"github.com/docker/go-connections/sockets"
+	"golang.org/x/sync/errgroup"
 )
 
-// SystemInfo returns information about the host server the daemon is running on.
-func (daemon *Daemon) SystemInfo() (*types.Info, error) {
-	kernelVersion := "<unknown>"
-	if kv, err := kernel.GetKernelVersion(); err != nil {
-		logrus.Warnf("Could not get kernel version: %v", err)
-	} else {
-		kernelVersion = kv.String()
+// infoCollectorTimeout bounds how long the platform info collector and any
+// collectors registered through RegisterInfoCollector may run before
+// SystemInfo gives up on them and reports a warning instead of blocking
+// the whole `docker info` response.
+const infoCollectorTimeout = 5 * time.Second
+
+// InfoCollectorFunc contributes additional fields to `docker info`. It is
+// handed a context bounded by infoCollectorTimeout and the in-progress
+// types.Info to fill in. An error it returns is surfaced to the caller as
+// a warning rather than failing the whole request.
+type InfoCollectorFunc func(ctx context.Context, v *types.Info) error
+
+var (
+	infoCollectorsMu sync.Mutex
+	infoCollectors   = map[string]InfoCollectorFunc{}
+)
+
+// RegisterInfoCollector registers fn to run on every SystemInfo call under
+// the given name. Optional subsystems (checkpoint, swarm, the plugin
+// manager, experimental features, ...) use this to contribute fields to
+// `docker info` without this file needing to know about them. Registering
+// a second collector under a name already in use replaces the first.
+func (daemon *Daemon) RegisterInfoCollector(name string, fn InfoCollectorFunc) {
+	infoCollectorsMu.Lock()
+	defer infoCollectorsMu.Unlock()
+	infoCollectors[name] = fn
+}
+
+// runInfoCollectors runs every registered collector concurrently, bounded
+// by ctx, turning any error a collector returns into a warning instead of
+// failing SystemInfo.
+func runInfoCollectors(ctx context.Context, v *types.Info) []string {
+	infoCollectorsMu.Lock()
+	collectors := make(map[string]InfoCollectorFunc, len(infoCollectors))
+	for name, fn := range infoCollectors {
+		collectors[name] = fn
 	}
+	infoCollectorsMu.Unlock()
 
-	operatingSystem := "<unknown>"
-	if s, err := operatingsystem.GetOperatingSystem(); err != nil {
-		logrus.Warnf("Could not get operating system name: %v", err)
-	} else {
-		operatingSystem = s
+	if len(collectors) == 0 {
+		return nil
+	}
+
+	var (
+		mu       sync.Mutex
+		warnings []string
+	)
+	eg, ctx := errgroup.WithContext(ctx)
+	for name, fn := range collectors {
+		name, fn := name, fn
+		eg.Go(func() error {
+			if err := fn(ctx, v); err != nil {
+				mu.Lock()
+				warnings = append(warnings, fmt.Sprintf("%s: %v", name, err))
+				mu.Unlock()
+			}
+			// A collector failing is reported as a warning above, never as
+			// a group error, so one slow or broken collector can't cancel
+			// the others.
+			return nil
+		})
 	}
+	eg.Wait()
+	return warnings
+}
 
+// SystemInfo returns information about the host server the daemon is
+// running on.
+func (daemon *Daemon) SystemInfo() (*types.Info, error) {
+	return daemon.systemInfo(false)
+}
+
+// SystemInfoBypassCache behaves like SystemInfo but forces every cached
+// field group in globalInfoCache (see info_cache.go) to be recomputed.
+// It's meant to be called by the API router's system.infoRoute instead
+// of SystemInfo when the request carries a `?bypass-cache=1` query
+// parameter, but that router isn't part of this change, so nothing
+// calls this yet.
+func (daemon *Daemon) SystemInfoBypassCache() (*types.Info, error) {
+	return daemon.systemInfo(true)
+}
+
+func (daemon *Daemon) systemInfo(bypassCache bool) (*types.Info, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), infoCollectorTimeout)
+	defer cancel()
+
+	static := daemon.staticSystemInfo(ctx)
+	semiStatic := daemon.semiStaticSystemInfo(bypassCache)
+	dyn := daemon.dynamicSystemInfo(bypassCache)
+
+	operatingSystem := static.operatingSystem
 	// Don't do containerized check on Windows
 	if runtime.GOOS != "windows" {
 		if inContainer, err := operatingsystem.IsContainerized(); err != nil {
@@ -52,76 +125,36 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 		}
 	}
 
-	meminfo, err := system.ReadMemInfo()
-	if err != nil {
-		logrus.Errorf("Could not read system memory info: %v", err)
-		meminfo = &system.MemInfo{}
-	}
-
 	sysInfo := sysinfo.New(true)
 
-	var cRunning, cPaused, cStopped int32
-	daemon.containers.ApplyAll(func(c *container.Container) {
-		switch c.StateString() {
-		case "paused":
-			atomic.AddInt32(&cPaused, 1)
-		case "running":
-			atomic.AddInt32(&cRunning, 1)
-		default:
-			atomic.AddInt32(&cStopped, 1)
-		}
-	})
-
-	securityOptions := []types.SecurityOpt{}
-	if sysInfo.AppArmor {
-		securityOptions = append(securityOptions, types.SecurityOpt{Key: "Name", Value: "apparmor"})
-	}
-	if sysInfo.Seccomp && supportsSeccomp {
-		profile := daemon.seccompProfilePath
-		if profile == "" {
-			profile = "default"
-		}
-		securityOptions = append(securityOptions,
-			types.SecurityOpt{Key: "Name", Value: "seccomp"},
-			types.SecurityOpt{Key: "Profile", Value: profile},
-		)
-	}
-	if selinuxEnabled() {
-		securityOptions = append(securityOptions, types.SecurityOpt{Key: "Name", Value: "selinux"})
-	}
-	uid, gid := daemon.GetRemappedUIDGID()
-	if uid != 0 || gid != 0 {
-		securityOptions = append(securityOptions, types.SecurityOpt{Key: "Name", Value: "userns"})
-	}
-
 	v := &types.InfoBase{
 		ID:                 daemon.ID,
-		Containers:         int(cRunning + cPaused + cStopped),
-		ContainersRunning:  int(cRunning),
-		ContainersPaused:   int(cPaused),
-		ContainersStopped:  int(cStopped),
-		Images:             len(daemon.imageStore.Map()),
+		Containers:         dyn.containers,
+		ContainersRunning:  dyn.containersRunning,
+		ContainersPaused:   dyn.containersPaused,
+		ContainersStopped:  dyn.containersStopped,
+		Images:             dyn.images,
 		Driver:             daemon.GraphDriverName(),
 		DriverStatus:       daemon.layerStore.DriverStatus(),
-		Plugins:            daemon.showPluginsInfo(),
+		Plugins:            semiStatic.plugins,
 		IPv4Forwarding:     !sysInfo.IPv4ForwardingDisabled,
 		BridgeNfIptables:   !sysInfo.BridgeNFCallIPTablesDisabled,
 		BridgeNfIP6tables:  !sysInfo.BridgeNFCallIP6TablesDisabled,
 		Debug:              utils.IsDebugEnabled(),
-		NFd:                fileutils.GetTotalUsedFds(),
-		NGoroutines:        runtime.NumGoroutine(),
-		SystemTime:         time.Now().Format(time.RFC3339Nano),
+		NFd:                dyn.nFd,
+		NGoroutines:        dyn.nGoroutines,
+		SystemTime:         dyn.systemTime,
 		LoggingDriver:      daemon.defaultLogConfig.Type,
 		CgroupDriver:       daemon.getCgroupDriver(),
-		NEventsListener:    daemon.EventsService.SubscribersCount(),
-		KernelVersion:      kernelVersion,
+		NEventsListener:    dyn.nEventsListener,
+		KernelVersion:      static.kernelVersion,
 		OperatingSystem:    operatingSystem,
 		IndexServerAddress: registry.IndexServer,
 		OSType:             platform.OSType,
 		Architecture:       platform.Architecture,
-		RegistryConfig:     daemon.RegistryService.ServiceConfig(),
+		RegistryConfig:     semiStatic.registryConfig,
 		NCPU:               sysinfo.NumCPU(),
-		MemTotal:           meminfo.MemTotal,
+		MemTotal:           dyn.memTotal,
 		DockerRootDir:      daemon.configStore.Root,
 		Labels:             daemon.configStore.Labels,
 		ExperimentalBuild:  daemon.configStore.Experimental,
@@ -133,91 +166,24 @@ func (daemon *Daemon) SystemInfo() (*types.Info, error) {
 		NoProxy:            sockets.GetProxyEnv("no_proxy"),
 		LiveRestoreEnabled: daemon.configStore.LiveRestoreEnabled,
 		Isolation:          daemon.defaultIsolation,
+		RuncCommit:         static.runcCommit,
+		InitCommit:         static.initCommit,
+		ContainerdCommit:   static.containerdCommit,
 	}
+	v.Name = static.hostname
 
-	// TODO Windows. Refactor this more once sysinfo is refactored into
-	// platform specific code. On Windows, sysinfo.cgroupMemInfo and
-	// sysinfo.cgroupCpuInfo will be nil otherwise and cause a SIGSEGV if
-	// an attempt is made to access through them.
-	if runtime.GOOS != "windows" {
-		v.MemoryLimit = sysInfo.MemoryLimit
-		v.SwapLimit = sysInfo.SwapLimit
-		v.KernelMemory = sysInfo.KernelMemory
-		v.OomKillDisable = sysInfo.OomKillDisable
-		v.CPUCfsPeriod = sysInfo.CPUCfsPeriod
-		v.CPUCfsQuota = sysInfo.CPUCfsQuota
-		v.CPUShares = sysInfo.CPUShares
-		v.CPUSet = sysInfo.Cpuset
-		v.Runtimes = daemon.configStore.GetAllRuntimes()
-		v.DefaultRuntime = daemon.configStore.GetDefaultRuntimeName()
-		v.InitBinary = daemon.configStore.GetInitPath()
-
-		v.ContainerdCommit.Expected = dockerversion.ContainerdCommitID
-		if sv, err := daemon.containerd.GetServerVersion(context.Background()); err == nil {
-			v.ContainerdCommit.ID = sv.Revision
-		} else {
-			logrus.Warnf("failed to retrieve containerd version: %v", err)
-			v.ContainerdCommit.ID = "N/A"
-		}
-
-		v.RuncCommit.Expected = dockerversion.RuncCommitID
-		if rv, err := exec.Command(DefaultRuntimeBinary, "--version").Output(); err == nil {
-			parts := strings.Split(strings.TrimSpace(string(rv)), "\n")
-			if len(parts) == 3 {
-				parts = strings.Split(parts[1], ": ")
-				if len(parts) == 2 {
-					v.RuncCommit.ID = strings.TrimSpace(parts[1])
-				}
-			}
-
-			if v.RuncCommit.ID == "" {
-				logrus.Warnf("failed to retrieve %s version: unknown output format: %s", DefaultRuntimeBinary, string(rv))
-				v.RuncCommit.ID = "N/A"
-			}
-		} else {
-			logrus.Warnf("failed to retrieve %s version: %v", DefaultRuntimeBinary, err)
-			v.RuncCommit.ID = "N/A"
-		}
-
-		v.InitCommit.Expected = dockerversion.InitCommitID
-		if rv, err := exec.Command(DefaultInitBinary, "--version").Output(); err == nil {
-			parts := strings.Split(strings.TrimSpace(string(rv)), " - ")
-			if len(parts) == 2 {
-				if dockerversion.InitCommitID[0] == 'v' {
-					vs := strings.TrimPrefix(parts[0], "tini version ")
-					v.InitCommit.ID = "v" + vs
-				} else {
-					// Get the sha1
-					gitParts := strings.Split(parts[1], ".")
-					if len(gitParts) == 2 && gitParts[0] == "git" {
-						v.InitCommit.ID = gitParts[1]
-						v.InitCommit.Expected = dockerversion.InitCommitID[0:len(gitParts[1])]
-					}
-				}
-			}
-
-			if v.InitCommit.ID == "" {
-				logrus.Warnf("failed to retrieve %s version: unknown output format: %s", DefaultInitBinary, string(rv))
-				v.InitCommit.ID = "N/A"
-			}
-		} else {
-			logrus.Warnf("failed to retrieve %s version", DefaultInitBinary)
-			v.InitCommit.ID = "N/A"
-		}
-	}
-
-	hostname := ""
-	if hn, err := os.Hostname(); err != nil {
-		logrus.Warnf("Could not get hostname: %v", err)
-	} else {
-		hostname = hn
+	// securityOptions and the remaining platform-scoped fields (cgroup
+	// limits, runtimes, ...) live in info_unix.go / info_windows.go.
+	securityOptions, err := daemon.fillPlatformInfo(ctx, v, sysInfo, semiStatic)
+	if err != nil {
+		logrus.Warnf("%v", err)
 	}
-	v.Name = hostname
 
 	i := &types.Info{
 		InfoBase:        v,
 		SecurityOptions: securityOptions,
 	}
+	i.Warnings = runInfoCollectors(ctx, i)
 
 	return i, nil
 }
@@ -255,3 +221,20 @@ func (daemon *Daemon) showPluginsInfo() types.PluginsInfo {
 
 	return pluginsInfo
 }
+
+// countContainers returns a point-in-time snapshot of container counts by
+// state. It's the expensive half of dynamicSystemInfo (an ApplyAll sweep
+// over every container), called fresh on every SystemInfo call.
+func (daemon *Daemon) countContainers() (running, paused, stopped int32) {
+	daemon.containers.ApplyAll(func(c *container.Container) {
+		switch c.StateString() {
+		case "paused":
+			atomic.AddInt32(&paused, 1)
+		case "running":
+			atomic.AddInt32(&running, 1)
+		default:
+			atomic.AddInt32(&stopped, 1)
+		}
+	})
+	return
+}
@@ -0,0 +1,202 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/parsers/kernel"
+	"github.com/docker/docker/pkg/parsers/operatingsystem"
+	"github.com/docker/docker/pkg/system"
+	"github.com/docker/docker/registry"
+)
+
+// staticSystemInfoT holds the SystemInfo fields that cannot change for
+// the lifetime of a daemon process. They're computed once, the first
+// time SystemInfo is called, and never invalidated.
+type staticSystemInfoT struct {
+	kernelVersion    string
+	operatingSystem  string
+	hostname         string
+	runcCommit       types.Commit
+	initCommit       types.Commit
+	containerdCommit types.Commit
+}
+
+// semiStaticInfoTTL is a safety net for the semi-static group: it's
+// primarily invalidated by a push from InvalidateInfoCache, but a poller
+// that lands between a Reload()/plugin event and whatever calls
+// InvalidateInfoCache for it would otherwise see a stale value for the
+// life of the process. Bound that to semiStaticInfoTTL instead.
+const semiStaticInfoTTL = 30 * time.Second
+
+// semiStaticSystemInfoT holds SystemInfo fields that change only in
+// response to a config reload or a registry/plugin event, not on every
+// request. It's invalidated by InvalidateInfoCache, backstopped by
+// semiStaticInfoTTL.
+type semiStaticSystemInfoT struct {
+	registryConfig *registry.ServiceConfig
+	plugins        types.PluginsInfo
+	runtimes       map[string]types.Runtime
+	defaultRuntime string
+	initBinary     string
+}
+
+// dynamicSystemInfoT holds SystemInfo fields that change on essentially
+// every call (container counts, fd/goroutine counts, SystemTime, memory,
+// ...). It's recomputed as a single snapshot on every SystemInfo call, so
+// fields like SystemTime are never stale.
+type dynamicSystemInfoT struct {
+	containers        int
+	containersRunning int
+	containersPaused  int
+	containersStopped int
+	images            int
+	nFd               int
+	nGoroutines       int
+	nEventsListener   int
+	systemTime        string
+	memTotal          uint64
+}
+
+type infoCacheT struct {
+	staticMu sync.Mutex
+	staticOK bool
+	static   staticSystemInfoT
+
+	semiStaticMu sync.Mutex
+	semiStaticAt time.Time
+	semiStatic   *semiStaticSystemInfoT
+}
+
+// globalInfoCache backs SystemInfo's caching. A daemon process hosts a
+// single Daemon, so this is scoped at the package level rather than
+// threaded through Daemon's (already very large) struct.
+var globalInfoCache infoCacheT
+
+// InvalidateInfoCache drops the semi-static group of cached SystemInfo
+// fields (registry config, plugins, runtimes) so the next `docker info`
+// recomputes them instead of serving a stale value. Until semiStaticInfoTTL
+// is hit, this is the only thing that does: neither Reload nor the plugin
+// manager nor the registry service call it yet (daemon.go/reload.go,
+// where that wiring belongs, aren't part of this change). The plugin
+// manager and registry service should call it directly on install/remove
+// and config-change events; Reload should call OnConfigReload below.
+func (daemon *Daemon) InvalidateInfoCache() {
+	globalInfoCache.semiStaticMu.Lock()
+	defer globalInfoCache.semiStaticMu.Unlock()
+	globalInfoCache.semiStatic = nil
+}
+
+// OnConfigReload invalidates the semi-static SystemInfo cache. It exists
+// for Reload to call after swapping in the new configStore, so the
+// registry config and runtimes list in the next `docker info` reflect a
+// reload immediately instead of waiting out semiStaticInfoTTL — but
+// Reload doesn't call it yet; that wiring belongs in daemon/reload.go,
+// which this change doesn't touch.
+func (daemon *Daemon) OnConfigReload() {
+	daemon.InvalidateInfoCache()
+}
+
+// staticSystemInfo returns the never-changing group of SystemInfo
+// fields. The runc/tini/containerd commit probes this depends on can
+// fail transiently (containerd isn't ready yet, a runtime binary is
+// momentarily unreachable), so unlike a plain sync.Once this only
+// memoizes the result once every probe in it has succeeded; until then
+// it retries on every call instead of caching "N/A" for the rest of the
+// process's life.
+func (daemon *Daemon) staticSystemInfo(ctx context.Context) staticSystemInfoT {
+	globalInfoCache.staticMu.Lock()
+	defer globalInfoCache.staticMu.Unlock()
+
+	if globalInfoCache.staticOK {
+		return globalInfoCache.static
+	}
+
+	s := staticSystemInfoT{
+		kernelVersion:   "<unknown>",
+		operatingSystem: "<unknown>",
+	}
+	if kv, err := kernel.GetKernelVersion(); err != nil {
+		logrus.Warnf("Could not get kernel version: %v", err)
+	} else {
+		s.kernelVersion = kv.String()
+	}
+	if o, err := operatingsystem.GetOperatingSystem(); err != nil {
+		logrus.Warnf("Could not get operating system name: %v", err)
+	} else {
+		s.operatingSystem = o
+	}
+	if hn, err := os.Hostname(); err != nil {
+		logrus.Warnf("Could not get hostname: %v", err)
+	} else {
+		s.hostname = hn
+	}
+
+	var ok bool
+	s.runcCommit, s.initCommit, s.containerdCommit, ok = daemon.probeRuntimeVersions(ctx)
+
+	globalInfoCache.static = s
+	globalInfoCache.staticOK = ok
+	return s
+}
+
+// semiStaticSystemInfo returns the registry-config/plugins/runtimes group
+// of SystemInfo fields, recomputing them only when bypassCache is set or
+// the cache has been invalidated since the last call.
+func (daemon *Daemon) semiStaticSystemInfo(bypassCache bool) semiStaticSystemInfoT {
+	globalInfoCache.semiStaticMu.Lock()
+	defer globalInfoCache.semiStaticMu.Unlock()
+
+	fresh := globalInfoCache.semiStatic != nil && time.Since(globalInfoCache.semiStaticAt) < semiStaticInfoTTL
+	if !bypassCache && fresh {
+		return *globalInfoCache.semiStatic
+	}
+
+	runtimes, defaultRuntime, initBinary := daemon.platformRuntimesInfo()
+	s := semiStaticSystemInfoT{
+		registryConfig: daemon.RegistryService.ServiceConfig(),
+		plugins:        daemon.showPluginsInfo(),
+		runtimes:       runtimes,
+		defaultRuntime: defaultRuntime,
+		initBinary:     initBinary,
+	}
+	globalInfoCache.semiStatic = &s
+	globalInfoCache.semiStaticAt = time.Now()
+	return s
+}
+
+// dynamicSystemInfo returns the frequently-changing group of SystemInfo
+// fields as a single snapshot. Unlike the static and semi-static groups
+// it is never cached: callers (monitoring agents polling `docker info`
+// chief among them) expect SystemTime and the container/fd/goroutine
+// counts to reflect the moment of the call, not some earlier poll.
+// bypassCache is accepted only for symmetry with the other two groups;
+// this group has nothing to bypass.
+func (daemon *Daemon) dynamicSystemInfo(bypassCache bool) dynamicSystemInfoT {
+	running, paused, stopped := daemon.countContainers()
+	meminfo, err := system.ReadMemInfo()
+	if err != nil {
+		logrus.Errorf("Could not read system memory info: %v", err)
+		meminfo = &system.MemInfo{}
+	}
+
+	d := dynamicSystemInfoT{
+		containers:        int(running + paused + stopped),
+		containersRunning: int(running),
+		containersPaused:  int(paused),
+		containersStopped: int(stopped),
+		images:            len(daemon.imageStore.Map()),
+		nFd:               fileutils.GetTotalUsedFds(),
+		nGoroutines:       runtime.NumGoroutine(),
+		nEventsListener:   daemon.EventsService.SubscribersCount(),
+		systemTime:        time.Now().Format(time.RFC3339Nano),
+		memTotal:          meminfo.MemTotal,
+	}
+	return d
+}
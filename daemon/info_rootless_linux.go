@@ -0,0 +1,99 @@
+// +build linux
+
+package daemon
+
+import (
+	"bufio"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/sysinfo"
+)
+
+// cgroupUnifiedMountpoint is present only when the host is using the
+// cgroup v2 unified hierarchy; its absence means cgroup v1.
+const cgroupUnifiedMountpoint = "/sys/fs/cgroup/cgroup.controllers"
+
+// rootlessInfo reports whether the daemon is running rootless (euid !=
+// 0), the subuid/subgid ranges it's using, which cgroup hierarchy version
+// the host has, and - when rootless - which cgroup-backed features are
+// degraded because the corresponding controller isn't delegated to the
+// unprivileged user.
+func (daemon *Daemon) rootlessInfo(sysInfo *sysinfo.SysInfo) types.RootlessInfo {
+	info := types.RootlessInfo{
+		Enabled:       os.Geteuid() != 0,
+		CgroupVersion: cgroupVersion(),
+	}
+
+	if !info.Enabled {
+		return info
+	}
+
+	if u, err := user.Current(); err != nil {
+		logrus.Warnf("rootless: could not determine current user: %v", err)
+	} else {
+		if r, ok := readSubIDRange("/etc/subuid", u.Username, u.Uid); ok {
+			info.SubUIDRange = r
+		}
+		if r, ok := readSubIDRange("/etc/subgid", u.Username, u.Uid); ok {
+			info.SubGIDRange = r
+		}
+	}
+
+	if !sysInfo.CPUShares {
+		info.DegradedSubsystems = append(info.DegradedSubsystems, "cpu")
+	}
+	if !sysInfo.MemoryLimit {
+		info.DegradedSubsystems = append(info.DegradedSubsystems, "memory")
+	}
+	if !sysInfo.OomKillDisable {
+		info.DegradedSubsystems = append(info.DegradedSubsystems, "oom-control")
+	}
+
+	return info
+}
+
+// cgroupVersion returns "2" if the host mounts the cgroup v2 unified
+// hierarchy, "1" otherwise.
+func cgroupVersion() string {
+	if _, err := os.Stat(cgroupUnifiedMountpoint); err == nil {
+		return "2"
+	}
+	return "1"
+}
+
+// readSubIDRange looks up name (falling back to uid) in an /etc/subuid or
+// /etc/subgid-formatted file and returns the configured range.
+func readSubIDRange(path, name, uid string) (types.IDRange, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		logrus.Warnf("rootless: could not read %s: %v", path, err)
+		return types.IDRange{}, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), ":")
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] != name && fields[0] != uid {
+			continue
+		}
+		start, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		length, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+		return types.IDRange{Start: start, Length: length}, true
+	}
+	return types.IDRange{}, false
+}
@@ -0,0 +1,17 @@
+// +build !windows,!linux
+
+package daemon
+
+import (
+	"os"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/sysinfo"
+)
+
+// rootlessInfo reports only whether the daemon is running unprivileged;
+// subuid/subgid ranges and cgroups are Linux-specific, so the rest of
+// types.RootlessInfo is left at its zero value on other Unix platforms.
+func (daemon *Daemon) rootlessInfo(sysInfo *sysinfo.SysInfo) types.RootlessInfo {
+	return types.RootlessInfo{Enabled: os.Geteuid() != 0}
+}
@@ -0,0 +1,134 @@
+// +build !windows
+
+package daemon
+
+import (
+	"context"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/runtimes"
+	"github.com/docker/docker/dockerversion"
+	"github.com/docker/docker/pkg/sysinfo"
+)
+
+// fillPlatformInfo fills the platform-specific parts of types.InfoBase
+// that the caching layer in info_cache.go doesn't already cover: the
+// cgroup-derived resource limits sysinfo detected, the runtimes/
+// default-runtime/init-binary fields taken from semiStatic, and the
+// security options available on this host.
+func (daemon *Daemon) fillPlatformInfo(ctx context.Context, v *types.InfoBase, sysInfo *sysinfo.SysInfo, semiStatic semiStaticSystemInfoT) ([]types.SecurityOpt, error) {
+	v.MemoryLimit = sysInfo.MemoryLimit
+	v.SwapLimit = sysInfo.SwapLimit
+	v.KernelMemory = sysInfo.KernelMemory
+	v.OomKillDisable = sysInfo.OomKillDisable
+	v.CPUCfsPeriod = sysInfo.CPUCfsPeriod
+	v.CPUCfsQuota = sysInfo.CPUCfsQuota
+	v.CPUShares = sysInfo.CPUShares
+	v.CPUSet = sysInfo.Cpuset
+	v.Runtimes = semiStatic.runtimes
+	v.DefaultRuntime = semiStatic.defaultRuntime
+	v.InitBinary = semiStatic.initBinary
+	v.Rootless = daemon.rootlessInfo(sysInfo)
+
+	securityOptions, err := daemon.securityOptions(sysInfo)
+	if err != nil {
+		return securityOptions, err
+	}
+	if v.Rootless.Enabled {
+		securityOptions = append(securityOptions, types.SecurityOpt{Key: "Name", Value: "rootless"})
+	}
+	return securityOptions, nil
+}
+
+// platformRuntimesInfo returns the runtimes/default-runtime/init-binary
+// group of fields for semiStaticSystemInfo to cache.
+func (daemon *Daemon) platformRuntimesInfo() (map[string]types.Runtime, string, string) {
+	return daemon.configStore.GetAllRuntimes(), daemon.configStore.GetDefaultRuntimeName(), daemon.configStore.GetInitPath()
+}
+
+// probeRuntimeVersions determines the runc/tini/containerd commits in
+// use. staticSystemInfo calls this on every cache miss, which in the
+// common case is only the first SystemInfo call of the process — but if
+// any of the three probes fails (e.g. containerd isn't ready yet during
+// daemon startup), staticSystemInfo deliberately doesn't cache the
+// result, so this runs again on the next call instead of reporting "N/A"
+// for the rest of the process's life. ok reports whether every probe
+// below succeeded. ctx bounds the runc/tini probes (see daemon/runtimes),
+// which shell out to external binaries and could otherwise stall daemon
+// startup.
+func (daemon *Daemon) probeRuntimeVersions(ctx context.Context) (runcCommit, initCommit, containerdCommit types.Commit, ok bool) {
+	ok = true
+
+	containerdCommit.Expected = dockerversion.ContainerdCommitID
+	if sv, err := daemon.containerd.GetServerVersion(ctx); err == nil {
+		containerdCommit.ID = sv.Revision
+	} else {
+		logrus.Warnf("failed to retrieve containerd version: %v", err)
+		containerdCommit.ID = "N/A"
+		ok = false
+	}
+
+	runcCommit.Expected = dockerversion.RuncCommitID
+	if rv, err := runtimes.Probe(ctx, DefaultRuntimeBinary, runtimes.RuncProbe{}); err == nil {
+		runcCommit.ID = rv.Commit
+		runcCommit.Spec = rv.Spec
+		if runcCommit.ID == "" {
+			runcCommit.ID = rv.Version
+		}
+	} else {
+		logrus.Warnf("failed to retrieve %s version: %v", DefaultRuntimeBinary, err)
+		runcCommit.ID = "N/A"
+		ok = false
+	}
+
+	initCommit.Expected = dockerversion.InitCommitID
+	if iv, err := runtimes.Probe(ctx, DefaultInitBinary, runtimes.TiniProbe{}); err == nil {
+		if len(dockerversion.InitCommitID) > 0 && dockerversion.InitCommitID[0] == 'v' {
+			initCommit.ID = "v" + iv.Version
+		} else if iv.Commit != "" {
+			initCommit.ID = iv.Commit
+			if len(iv.Commit) < len(dockerversion.InitCommitID) {
+				initCommit.Expected = dockerversion.InitCommitID[:len(iv.Commit)]
+			}
+		}
+		if initCommit.ID == "" {
+			logrus.Warnf("failed to retrieve %s version: unexpected output: %+v", DefaultInitBinary, iv)
+			initCommit.ID = "N/A"
+			ok = false
+		}
+	} else {
+		logrus.Warnf("failed to retrieve %s version: %v", DefaultInitBinary, err)
+		initCommit.ID = "N/A"
+		ok = false
+	}
+
+	return runcCommit, initCommit, containerdCommit, ok
+}
+
+// securityOptions reports the security features (apparmor, seccomp,
+// selinux, userns) enabled for this daemon.
+func (daemon *Daemon) securityOptions(sysInfo *sysinfo.SysInfo) ([]types.SecurityOpt, error) {
+	securityOptions := []types.SecurityOpt{}
+	if sysInfo.AppArmor {
+		securityOptions = append(securityOptions, types.SecurityOpt{Key: "Name", Value: "apparmor"})
+	}
+	if sysInfo.Seccomp && supportsSeccomp {
+		profile := daemon.seccompProfilePath
+		if profile == "" {
+			profile = "default"
+		}
+		securityOptions = append(securityOptions,
+			types.SecurityOpt{Key: "Name", Value: "seccomp"},
+			types.SecurityOpt{Key: "Profile", Value: profile},
+		)
+	}
+	if selinuxEnabled() {
+		securityOptions = append(securityOptions, types.SecurityOpt{Key: "Name", Value: "selinux"})
+	}
+	uid, gid := daemon.GetRemappedUIDGID()
+	if uid != 0 || gid != 0 {
+		securityOptions = append(securityOptions, types.SecurityOpt{Key: "Name", Value: "userns"})
+	}
+	return securityOptions, nil
+}
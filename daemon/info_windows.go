@@ -0,0 +1,31 @@
+package daemon
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/sysinfo"
+)
+
+// fillPlatformInfo fills the platform-specific parts of types.InfoBase.
+// On Windows there is no cgroup data to report and sysinfo.cgroupMemInfo
+// / sysinfo.cgroupCpuInfo are nil, so this is a light no-op compared to
+// its Unix counterpart in info_unix.go.
+func (daemon *Daemon) fillPlatformInfo(ctx context.Context, v *types.InfoBase, sysInfo *sysinfo.SysInfo, semiStatic semiStaticSystemInfoT) ([]types.SecurityOpt, error) {
+	return []types.SecurityOpt{}, nil
+}
+
+// platformRuntimesInfo returns the runtimes/default-runtime/init-binary
+// group of fields for semiStaticSystemInfo to cache. Windows has no
+// notion of alternate OCI runtimes.
+func (daemon *Daemon) platformRuntimesInfo() (map[string]types.Runtime, string, string) {
+	return nil, "", ""
+}
+
+// probeRuntimeVersions is a no-op on Windows: there is no runc/tini
+// binary to probe, and containerd version reporting isn't wired up here.
+// ok is always true so staticSystemInfo caches this result instead of
+// retrying it on every call.
+func (daemon *Daemon) probeRuntimeVersions(ctx context.Context) (runcCommit, initCommit, containerdCommit types.Commit, ok bool) {
+	return types.Commit{}, types.Commit{}, types.Commit{}, true
+}
@@ -0,0 +1,152 @@
+// Package metrics exports daemon-internal counters (the same ones
+// SystemInfo builds for `docker info`) as Prometheus metrics, so
+// operators can scrape them instead of polling the JSON /info endpoint.
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Source supplies the daemon-internal counters Collector exports. It's
+// implemented by an adapter in the daemon package so this package has no
+// dependency on daemon itself.
+type Source interface {
+	ContainerCounts() (running, paused, stopped int)
+	ImageCount() int
+	OpenFDs() int
+	Goroutines() int
+	MemTotal() uint64
+	EventListeners() int
+	GraphDriver() (name string, status map[string]string)
+	Runtimes() (names []string, defaultRuntime string)
+}
+
+// stableGraphDriverFields whitelists the graphdriver status fields that
+// are safe to turn into a label value: they identify configuration, not
+// a constantly-changing measurement. Fields like devicemapper's "Data
+// Space Used" churn on every write and would otherwise mint a new time
+// series on every scrape; leave those out of graphdriver_status entirely
+// rather than exporting them as labels.
+var stableGraphDriverFields = map[string]bool{
+	"Pool Name":           true,
+	"Pool Blocksize":      true,
+	"Base Device Size":    true,
+	"Backing Filesystem":  true,
+	"Udev Sync Supported": true,
+	"Data loop file":      true,
+	"Metadata loop file":  true,
+	"Library Version":     true,
+}
+
+// Collector is a prometheus.Collector that mirrors the figures SystemInfo
+// reports: container/image counts, fd/goroutine counts, memory, and
+// per-graphdriver and per-runtime labeled gauges. It holds only the
+// static *Desc for each metric; Collect builds every prometheus.Metric
+// fresh from Source on each call so concurrent scrapes can't observe
+// each other's in-progress state.
+type Collector struct {
+	source Source
+
+	containersDesc *prometheus.Desc
+	imagesDesc     *prometheus.Desc
+	openFDsDesc    *prometheus.Desc
+	goroutinesDesc *prometheus.Desc
+	memTotalDesc   *prometheus.Desc
+	eventsDesc     *prometheus.Desc
+	driverDesc     *prometheus.Desc
+	runtimeDesc    *prometheus.Desc
+}
+
+// NewCollector builds a Collector that reads its values from source on
+// every scrape.
+func NewCollector(source Source) *Collector {
+	ns, sub := "docker", "daemon"
+	return &Collector{
+		source: source,
+		containersDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, sub, "containers"),
+			"Number of containers by state.",
+			[]string{"state"}, nil,
+		),
+		imagesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, sub, "images"),
+			"Number of images known to the daemon.",
+			nil, nil,
+		),
+		openFDsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, sub, "open_fds"),
+			"Number of file descriptors currently open by the daemon.",
+			nil, nil,
+		),
+		goroutinesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, sub, "goroutines"),
+			"Number of goroutines currently running in the daemon.",
+			nil, nil,
+		),
+		memTotalDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, sub, "mem_total_bytes"),
+			"Total system memory visible to the daemon, in bytes.",
+			nil, nil,
+		),
+		eventsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, sub, "event_listeners"),
+			"Number of clients currently subscribed to the events stream.",
+			nil, nil,
+		),
+		driverDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, sub, "graphdriver_status"),
+			"Stable graphdriver status fields reported by `docker info`, always 1.",
+			[]string{"driver", "field", "value"}, nil,
+		),
+		runtimeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, sub, "runtime"),
+			"Configured OCI runtimes, always 1; is_default marks the default one.",
+			[]string{"runtime", "is_default"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.containersDesc
+	ch <- c.imagesDesc
+	ch <- c.openFDsDesc
+	ch <- c.goroutinesDesc
+	ch <- c.memTotalDesc
+	ch <- c.eventsDesc
+	ch <- c.driverDesc
+	ch <- c.runtimeDesc
+}
+
+// Collect implements prometheus.Collector, reading Source once per
+// scrape and emitting each value as a fresh prometheus.Metric built
+// against the collector's static Descs. It deliberately never mutates
+// any state shared between calls, since promhttp serves scrapes
+// concurrently.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	running, paused, stopped := c.source.ContainerCounts()
+	ch <- prometheus.MustNewConstMetric(c.containersDesc, prometheus.GaugeValue, float64(running), "running")
+	ch <- prometheus.MustNewConstMetric(c.containersDesc, prometheus.GaugeValue, float64(paused), "paused")
+	ch <- prometheus.MustNewConstMetric(c.containersDesc, prometheus.GaugeValue, float64(stopped), "stopped")
+
+	ch <- prometheus.MustNewConstMetric(c.imagesDesc, prometheus.GaugeValue, float64(c.source.ImageCount()))
+	ch <- prometheus.MustNewConstMetric(c.openFDsDesc, prometheus.GaugeValue, float64(c.source.OpenFDs()))
+	ch <- prometheus.MustNewConstMetric(c.goroutinesDesc, prometheus.GaugeValue, float64(c.source.Goroutines()))
+	ch <- prometheus.MustNewConstMetric(c.memTotalDesc, prometheus.GaugeValue, float64(c.source.MemTotal()))
+	ch <- prometheus.MustNewConstMetric(c.eventsDesc, prometheus.GaugeValue, float64(c.source.EventListeners()))
+
+	driverName, status := c.source.GraphDriver()
+	for field, value := range status {
+		if !stableGraphDriverFields[field] {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.driverDesc, prometheus.GaugeValue, 1, driverName, field, value)
+	}
+
+	names, defaultRuntime := c.source.Runtimes()
+	for _, name := range names {
+		isDefault := "false"
+		if name == defaultRuntime {
+			isDefault = "true"
+		}
+		ch <- prometheus.MustNewConstMetric(c.runtimeDesc, prometheus.GaugeValue, 1, name, isDefault)
+	}
+}
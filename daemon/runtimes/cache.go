@@ -0,0 +1,49 @@
+package runtimes
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// cacheKey identifies a binary by path plus enough of its stat info
+// (inode and mtime) to know when it's been replaced, so a probe only
+// re-runs the binary when it's actually changed.
+type cacheKey struct {
+	path  string
+	inode uint64
+	mtime int64
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[cacheKey]Version{}
+)
+
+// Probe runs p against the binary at path, caching the result keyed by
+// the binary's inode and mtime so repeated SystemInfo calls don't
+// re-exec it until the binary on disk actually changes.
+func Probe(ctx context.Context, path string, p VersionProbe) (Version, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Version{}, err
+	}
+	key := cacheKey{path: path, inode: fileInode(fi), mtime: fi.ModTime().UnixNano()}
+
+	cacheMu.Lock()
+	if v, ok := cache[key]; ok {
+		cacheMu.Unlock()
+		return v, nil
+	}
+	cacheMu.Unlock()
+
+	v, err := p.Probe(ctx, path)
+	if err != nil {
+		return Version{}, err
+	}
+
+	cacheMu.Lock()
+	cache[key] = v
+	cacheMu.Unlock()
+	return v, nil
+}
@@ -0,0 +1,18 @@
+// +build !windows
+
+package runtimes
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileInode returns fi's inode number, used to detect a binary being
+// replaced (e.g. by a package upgrade) even if the replacement happens
+// to share the old file's mtime.
+func fileInode(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
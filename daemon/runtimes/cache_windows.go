@@ -0,0 +1,8 @@
+package runtimes
+
+import "os"
+
+// fileInode has no equivalent on Windows; mtime alone gates the cache.
+func fileInode(fi os.FileInfo) uint64 {
+	return 0
+}
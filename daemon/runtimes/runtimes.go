@@ -0,0 +1,106 @@
+// Package runtimes provides resilient, typed version probes for the
+// external binaries the daemon shells out to (runc, tini). Each probe
+// tries a structured output format first and falls back to regular
+// expressions with named capture groups, instead of assuming stdout has
+// an exact number of lines and panicking-by-omission (silently yielding
+// "N/A") the moment that format drifts.
+package runtimes
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Version is the result of probing a runtime binary's version.
+type Version struct {
+	Version string
+	Commit  string
+	Spec    string // OCI runtime-spec version, when the binary reports one.
+}
+
+// VersionProbe determines the Version of the binary at path.
+type VersionProbe interface {
+	Probe(ctx context.Context, path string) (Version, error)
+}
+
+// jsonVersion is the shape probes expect from a binary's structured
+// version output, when it has one.
+type jsonVersion struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Spec    string `json:"spec"`
+}
+
+// RuncProbe probes a runc-compatible binary.
+type RuncProbe struct{}
+
+// Probe implements VersionProbe for runc. It first tries
+// `<path> --version --format json`, which newer runc builds support, and
+// falls back to parsing plaintext `--version` output line-by-line.
+func (RuncProbe) Probe(ctx context.Context, path string) (Version, error) {
+	if out, err := exec.CommandContext(ctx, path, "--version", "--format", "json").Output(); err == nil {
+		var jv jsonVersion
+		if json.Unmarshal(out, &jv) == nil && jv.Version != "" {
+			return Version{Version: jv.Version, Commit: jv.Commit, Spec: jv.Spec}, nil
+		}
+	}
+
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return Version{}, err
+	}
+
+	var v Version
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		switch {
+		case strings.HasPrefix(line, "runc version"):
+			v.Version = strings.TrimSpace(strings.TrimPrefix(line, "runc version"))
+		case strings.HasPrefix(line, "commit:"):
+			v.Commit = strings.TrimSpace(strings.TrimPrefix(line, "commit:"))
+		case strings.HasPrefix(line, "spec:"):
+			v.Spec = strings.TrimSpace(strings.TrimPrefix(line, "spec:"))
+		}
+	}
+	if v.Version == "" && v.Commit == "" {
+		return Version{}, errUnknownFormat(string(out))
+	}
+	return v, nil
+}
+
+// TiniProbe probes a tini-compatible init binary.
+type TiniProbe struct{}
+
+// tiniVersionLine matches `tini version X` or `tini version X - git.SHA`.
+var tiniVersionLine = regexp.MustCompile(`^tini version (?P<version>\S+)(?: - (?P<rest>.+))?$`)
+
+// Probe implements VersionProbe for tini. tini has no structured output
+// mode, so this only does the regex-based parse.
+func (TiniProbe) Probe(ctx context.Context, path string) (Version, error) {
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return Version{}, err
+	}
+
+	m := tiniVersionLine.FindStringSubmatch(strings.TrimSpace(string(out)))
+	if m == nil {
+		return Version{}, errUnknownFormat(string(out))
+	}
+
+	v := Version{Version: m[1]}
+	if rest := m[2]; rest != "" {
+		// "git.<sha>" style commit suffix, e.g. "10.5.0 - git.faf7ba6".
+		if gitParts := strings.SplitN(rest, ".", 2); len(gitParts) == 2 && gitParts[0] == "git" {
+			v.Commit = gitParts[1]
+		}
+	}
+	return v, nil
+}
+
+type errUnknownFormat string
+
+func (e errUnknownFormat) Error() string {
+	return "unknown version output format: " + string(e)
+}
@@ -0,0 +1,92 @@
+package runtimes
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeBinary writes an executable shell script at <dir>/<name> that
+// prints output to stdout depending on its arguments, and returns its
+// path.
+func fakeBinary(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRuncProbePlaintextFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runtimes-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := fakeBinary(t, dir, "runc", `
+if [ "$1" = "--version" ] && [ "$2" = "--format" ]; then
+  exit 1
+fi
+echo "runc version 1.0.0-rc10"
+echo "commit: dc9208a3303feef5b3839f4323d9beb36df0a9dd"
+echo "spec: 1.0.1-dev"
+`)
+
+	v, err := RuncProbe{}.Probe(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	if v.Version != "1.0.0-rc10" || v.Commit != "dc9208a3303feef5b3839f4323d9beb36df0a9dd" || v.Spec != "1.0.1-dev" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
+
+func TestRuncProbeStructuredOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runtimes-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := fakeBinary(t, dir, "runc", `echo '{"version":"1.1.0","commit":"abc123","spec":"1.0.2"}'`)
+
+	v, err := RuncProbe{}.Probe(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	if v != (Version{Version: "1.1.0", Commit: "abc123", Spec: "1.0.2"}) {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
+
+func TestTiniVersionLineMatchesKnownFormats(t *testing.T) {
+	cases := []struct {
+		line        string
+		wantVersion string
+		wantMatch   bool
+	}{
+		{"tini version 0.19.0", "0.19.0", true},
+		{"tini version 0.19.0 - git.faf7ba6", "0.19.0", true},
+		{"not tini at all", "", false},
+	}
+	for _, c := range cases {
+		m := tiniVersionLine.FindStringSubmatch(c.line)
+		if c.wantMatch && m == nil {
+			t.Errorf("expected %q to match", c.line)
+			continue
+		}
+		if !c.wantMatch {
+			if m != nil {
+				t.Errorf("expected %q not to match", c.line)
+			}
+			continue
+		}
+		if m[1] != c.wantVersion {
+			t.Errorf("line %q: got version %q, want %q", c.line, m[1], c.wantVersion)
+		}
+	}
+}